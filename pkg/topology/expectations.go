@@ -0,0 +1,147 @@
+// Package topology holds the evaluation logic for deciding whether a workload's replica count
+// is consistent with a cluster's topology mode. It's used by the
+// "[sig-arch] Cluster topology single node tests" e2e, and is kept dependency-light so an
+// in-cluster controller owned by the relevant operator can import it too and reach the same
+// verdict, without origin needing to ship or run that controller itself.
+package topology
+
+import (
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// WorkloadKind identifies the kind of workload a TopologyExpectation applies to.
+type WorkloadKind string
+
+const (
+	KindDeployment              WorkloadKind = "Deployment"
+	KindStatefulSet             WorkloadKind = "StatefulSet"
+	KindDaemonSet               WorkloadKind = "DaemonSet"
+	KindCronJob                 WorkloadKind = "CronJob"
+	KindJob                     WorkloadKind = "Job"
+	KindHorizontalPodAutoscaler WorkloadKind = "HorizontalPodAutoscaler"
+)
+
+// Expectation declares how a single (namespace, name, kind) workload is expected to behave
+// across topology modes: whether it's classified as infrastructure (vs. control plane), and
+// whether it's still allowed to violate that classification while its owning bug is open.
+type Expectation struct {
+	Namespace      string       `json:"namespace"`
+	Name           string       `json:"name"`
+	Kind           WorkloadKind `json:"kind"`
+	Infrastructure bool         `json:"infrastructure"`
+	AllowedToFail  bool         `json:"allowedToFail"`
+	BugID          string       `json:"bugID,omitempty"`
+}
+
+// Expectations is the full set of per-workload expectations workloads are evaluated against.
+type Expectations struct {
+	Entries []Expectation
+}
+
+func (e Expectations) find(kind WorkloadKind, name, namespace string) (Expectation, bool) {
+	for _, entry := range e.Entries {
+		if entry.Kind == kind && entry.Name == name && entry.Namespace == namespace {
+			return entry, true
+		}
+	}
+
+	return Expectation{}, false
+}
+
+// IsInfrastructure reports whether the named workload is classified as infrastructure, as
+// opposed to control plane.
+func (e Expectations) IsInfrastructure(kind WorkloadKind, name, namespace string) bool {
+	entry, ok := e.find(kind, name, namespace)
+	return ok && entry.Infrastructure
+}
+
+// IsAllowedToFail reports whether the named workload is still on the allow-list.
+func (e Expectations) IsAllowedToFail(kind WorkloadKind, name, namespace string) bool {
+	entry, ok := e.find(kind, name, namespace)
+	return ok && entry.AllowedToFail
+}
+
+// Embedded returns the expectations compiled into this code, mirroring the historical
+// compiled-in allow-list and infrastructure classification. It's used as a fallback by anything
+// that can't or didn't load a TopologyExpectations ConfigMap.
+func Embedded() Expectations {
+	return Expectations{
+		Entries: []Expectation{
+			{Namespace: "openshift-ingress", Name: "router-default", Kind: KindDeployment, Infrastructure: true},
+
+			{Namespace: "openshift-authentication", Name: "oauth-openshift", Kind: KindDeployment, AllowedToFail: true},
+			{Namespace: "openshift-console", Name: "console", Kind: KindDeployment, AllowedToFail: true},
+			{Namespace: "openshift-console", Name: "downloads", Kind: KindDeployment, AllowedToFail: true},
+			{Namespace: "openshift-image-registry", Name: "image-registry", Kind: KindDeployment, AllowedToFail: true},
+			{Namespace: "openshift-monitoring", Name: "prometheus-adapter", Kind: KindDeployment, AllowedToFail: true},
+			{Namespace: "openshift-monitoring", Name: "thanos-querier", Kind: KindDeployment, AllowedToFail: true},
+			{Namespace: "openshift-monitoring", Name: "alertmanager-main", Kind: KindStatefulSet, AllowedToFail: true},
+			{Namespace: "openshift-monitoring", Name: "prometheus-k8s", Kind: KindStatefulSet, AllowedToFail: true},
+			{Namespace: "openshift-operator-lifecycle-manager", Name: "packageserver", Kind: KindDeployment, AllowedToFail: true},
+		},
+	}
+}
+
+// DualReplicaTopologyMode is the proposed Two-Node topology mode: two control plane replicas,
+// neither of which is a tiebreaker. It isn't part of github.com/openshift/api yet, so it's
+// declared here until it lands upstream.
+const DualReplicaTopologyMode configv1.TopologyMode = "DualReplica"
+
+// MasterNodeRoleLabel is set on every control plane node, regardless of topology mode, and is
+// what the expected replica count for HighlyAvailable workloads should be computed from - not
+// the cluster's total node count, which also includes workers.
+const MasterNodeRoleLabel = "node-role.kubernetes.io/master"
+
+// ExpectedReplicas computes the replica count a workload is expected to run with under the given
+// topology mode. ok is false when there's no fixed count to assert against: an External-topology
+// workload is expected to run on the management cluster rather than this one, and a
+// HighlyAvailable workload's expectation depends on the control plane node count, which the
+// caller supplies (0 or less meaning "unknown" - e.g. the caller couldn't enumerate nodes).
+func ExpectedReplicas(relevantTopology configv1.TopologyMode, controlPlaneNodeCount int) (expected int, ok bool) {
+	switch relevantTopology {
+	case configv1.SingleReplicaTopologyMode:
+		return 1, true
+	case DualReplicaTopologyMode:
+		return 2, true
+	case configv1.HighlyAvailableTopologyMode:
+		if controlPlaneNodeCount <= 0 {
+			return 0, false
+		}
+		return controlPlaneNodeCount, true
+	case configv1.ExternalTopologyMode:
+		// Hosted on the management cluster; nothing on this cluster to assert against.
+		return 0, false
+	default:
+		return 0, false
+	}
+}
+
+func relevantTopology(isInfrastructure bool, controlPlaneTopology, infraTopology configv1.TopologyMode) configv1.TopologyMode {
+	if isInfrastructure {
+		return infraTopology
+	}
+
+	return controlPlaneTopology
+}
+
+// Applicable reports whether this package has a fixed replica expectation for a workload, given
+// its infra/control-plane classification and the cluster's topology. Callers should skip a
+// workload entirely when this is false, rather than treating "no expectation" as a pass or fail.
+func Applicable(isInfrastructure bool, controlPlaneTopology, infraTopology configv1.TopologyMode, controlPlaneNodeCount int) bool {
+	_, ok := ExpectedReplicas(relevantTopology(isInfrastructure, controlPlaneTopology, infraTopology), controlPlaneNodeCount)
+	return ok
+}
+
+// IsReplicaCountExpected evaluates a workload's replica count against the topology mode that
+// applies to it. infrastructure workloads are evaluated against infraTopology, everything else
+// against controlPlaneTopology. applicable is false when the relevant topology has no fixed
+// replica expectation (see ExpectedReplicas), in which case ok is meaningless and the caller
+// should skip the workload.
+func IsReplicaCountExpected(isInfrastructure bool, controlPlaneTopology, infraTopology configv1.TopologyMode, controlPlaneNodeCount, replicas int) (applicable, ok bool) {
+	expected, applicable := ExpectedReplicas(relevantTopology(isInfrastructure, controlPlaneTopology, infraTopology), controlPlaneNodeCount)
+	if !applicable {
+		return false, false
+	}
+
+	return true, replicas == expected
+}
@@ -0,0 +1,110 @@
+package topology
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestExpectedReplicas(t *testing.T) {
+	tests := []struct {
+		name       string
+		topology   configv1.TopologyMode
+		nodeCount  int
+		expected   int
+		applicable bool
+	}{
+		{name: "single replica", topology: configv1.SingleReplicaTopologyMode, nodeCount: 1, expected: 1, applicable: true},
+		{name: "dual replica", topology: DualReplicaTopologyMode, nodeCount: 2, expected: 2, applicable: true},
+		{name: "highly available with known node count", topology: configv1.HighlyAvailableTopologyMode, nodeCount: 3, expected: 3, applicable: true},
+		{name: "highly available with unknown node count", topology: configv1.HighlyAvailableTopologyMode, nodeCount: 0, applicable: false},
+		{name: "external is hosted elsewhere", topology: configv1.ExternalTopologyMode, nodeCount: 1, applicable: false},
+		{name: "unknown topology mode", topology: configv1.TopologyMode("SomethingNew"), nodeCount: 1, applicable: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, ok := ExpectedReplicas(tt.topology, tt.nodeCount)
+			if ok != tt.applicable {
+				t.Fatalf("ExpectedReplicas(%s, %d) applicable = %v, want %v", tt.topology, tt.nodeCount, ok, tt.applicable)
+			}
+			if ok && expected != tt.expected {
+				t.Fatalf("ExpectedReplicas(%s, %d) = %d, want %d", tt.topology, tt.nodeCount, expected, tt.expected)
+			}
+		})
+	}
+}
+
+// TestIsReplicaCountExpected exercises a synthetic namespace's worth of workloads covering each
+// topology mode, asserting the verdict IsReplicaCountExpected reaches for each. The e2e and any
+// in-cluster controller importing this package share that verdict.
+func TestIsReplicaCountExpected(t *testing.T) {
+	tests := []struct {
+		name                 string
+		isInfrastructure     bool
+		controlPlaneTopology configv1.TopologyMode
+		infraTopology        configv1.TopologyMode
+		nodeCount            int
+		replicas             int
+		wantApplicable       bool
+		wantOK               bool
+	}{
+		{
+			name:                 "control plane deployment on SingleReplica with one replica",
+			controlPlaneTopology: configv1.SingleReplicaTopologyMode,
+			infraTopology:        configv1.HighlyAvailableTopologyMode,
+			nodeCount:            1,
+			replicas:             1,
+			wantApplicable:       true,
+			wantOK:               true,
+		},
+		{
+			name:                 "control plane deployment on SingleReplica with two replicas",
+			controlPlaneTopology: configv1.SingleReplicaTopologyMode,
+			infraTopology:        configv1.HighlyAvailableTopologyMode,
+			nodeCount:            1,
+			replicas:             2,
+			wantApplicable:       true,
+			wantOK:               false,
+		},
+		{
+			name:                 "infra deployment follows infraTopology, not controlPlaneTopology",
+			isInfrastructure:     true,
+			controlPlaneTopology: configv1.SingleReplicaTopologyMode,
+			infraTopology:        DualReplicaTopologyMode,
+			nodeCount:            2,
+			replicas:             2,
+			wantApplicable:       true,
+			wantOK:               true,
+		},
+		{
+			name:                 "External topology workload is skipped entirely",
+			controlPlaneTopology: configv1.ExternalTopologyMode,
+			infraTopology:        configv1.ExternalTopologyMode,
+			nodeCount:            0,
+			replicas:             1,
+			wantApplicable:       false,
+		},
+		{
+			name:                 "HighlyAvailable deployment matches node count",
+			controlPlaneTopology: configv1.HighlyAvailableTopologyMode,
+			infraTopology:        configv1.HighlyAvailableTopologyMode,
+			nodeCount:            3,
+			replicas:             3,
+			wantApplicable:       true,
+			wantOK:               true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applicable, ok := IsReplicaCountExpected(tt.isInfrastructure, tt.controlPlaneTopology, tt.infraTopology, tt.nodeCount, tt.replicas)
+			if applicable != tt.wantApplicable {
+				t.Fatalf("applicable = %v, want %v", applicable, tt.wantApplicable)
+			}
+			if applicable && ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package single_node
+
+import (
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/origin/test/extended/single_node/statuscheck"
+	exutil "github.com/openshift/origin/test/extended/util"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+const (
+	readinessPollInterval = 5 * time.Second
+	readinessTimeout      = 5 * time.Minute
+)
+
+// waitForDeploymentReady waits for a Deployment to finish rolling out before its replica count
+// is inspected, so a component that was just updated to honor the cluster's topology but hasn't
+// scaled yet doesn't get flagged as a false positive. It returns the freshest copy observed.
+func waitForDeploymentReady(f *e2e.Framework, deployment appsv1.Deployment) appsv1.Deployment {
+	latest := deployment
+
+	err := wait.PollImmediate(readinessPollInterval, readinessTimeout, func() (bool, error) {
+		current, err := exutil.GetWithRetry(f.ClientSet.AppsV1().Deployments(deployment.Namespace).Get, deployment.Name)
+		if err != nil {
+			return false, err
+		}
+
+		latest = *current
+		return statuscheck.DeploymentReady(current), nil
+	})
+	Expect(err).NotTo(HaveOccurred(), "%s in %s namespace did not become ready", deployment.Name, deployment.Namespace)
+
+	return latest
+}
+
+// waitForStatefulSetReady waits for a StatefulSet to finish rolling out before its replica count
+// is inspected. It returns the freshest copy observed.
+func waitForStatefulSetReady(f *e2e.Framework, statefulSet appsv1.StatefulSet) appsv1.StatefulSet {
+	latest := statefulSet
+
+	err := wait.PollImmediate(readinessPollInterval, readinessTimeout, func() (bool, error) {
+		current, err := exutil.GetWithRetry(f.ClientSet.AppsV1().StatefulSets(statefulSet.Namespace).Get, statefulSet.Name)
+		if err != nil {
+			return false, err
+		}
+
+		latest = *current
+		return statuscheck.StatefulSetReady(current), nil
+	})
+	Expect(err).NotTo(HaveOccurred(), "%s in %s namespace did not become ready", statefulSet.Name, statefulSet.Namespace)
+
+	return latest
+}
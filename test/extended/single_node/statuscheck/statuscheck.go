@@ -0,0 +1,33 @@
+// Package statuscheck mirrors the subset of kstatus (as used by Helm 3.5's resource readiness
+// check) needed to tell whether a Deployment or StatefulSet has finished rolling out, without
+// pulling in kstatus itself.
+package statuscheck
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// DeploymentReady reports whether a Deployment is Current in kstatus terms: the controller has
+// observed the latest spec, and every desired replica has been updated and is available.
+func DeploymentReady(deployment *appsv1.Deployment) bool {
+	if deployment.Spec.Replicas == nil {
+		return false
+	}
+
+	desired := *deployment.Spec.Replicas
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == desired &&
+		deployment.Status.AvailableReplicas == desired
+}
+
+// StatefulSetReady reports whether a StatefulSet is Current in kstatus terms: every desired
+// replica is ready, and the current revision has caught up with the update revision.
+func StatefulSetReady(statefulSet *appsv1.StatefulSet) bool {
+	if statefulSet.Spec.Replicas == nil {
+		return false
+	}
+
+	desired := *statefulSet.Spec.Replicas
+	return statefulSet.Status.ReadyReplicas == desired &&
+		statefulSet.Status.CurrentRevision == statefulSet.Status.UpdateRevision
+}
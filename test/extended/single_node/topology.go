@@ -5,8 +5,11 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	v1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/origin/pkg/topology"
 	exutil "github.com/openshift/origin/test/extended/util"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
@@ -14,8 +17,26 @@ import (
 	"strings"
 )
 
+// topologyContext bundles everything a WorkloadValidator needs to judge whether a workload's
+// scaling configuration matches the cluster's topology.
+type topologyContext struct {
+	framework            *e2e.Framework
+	controlPlaneTopology v1.TopologyMode
+	infraTopology        v1.TopologyMode
+	nodeCount            int
+	expectations         TopologyExpectations
+}
+
+// WorkloadValidator is implemented by every workload kind this test knows how to check for
+// topology-consistent scaling behavior. Each kind interprets "topology-consistent" differently:
+// replica counts for Deployments/StatefulSets, desired pod count for DaemonSets, concurrency
+// settings for CronJobs/Jobs, and replica bounds for HorizontalPodAutoscalers.
+type WorkloadValidator interface {
+	Validate(ctx topologyContext)
+}
+
 func getOpenshiftNamespaces(f *e2e.Framework) []corev1.Namespace {
-	list, err := f.ClientSet.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	list, err := exutil.ListWithRetry(f.ClientSet.CoreV1().Namespaces().List)
 	Expect(err).NotTo(HaveOccurred())
 
 	var openshiftNamespaces []corev1.Namespace
@@ -29,19 +50,79 @@ func getOpenshiftNamespaces(f *e2e.Framework) []corev1.Namespace {
 }
 
 func getNamespaceDeployments(f *e2e.Framework, namespace corev1.Namespace) []appsv1.Deployment {
-	list, err := f.ClientSet.AppsV1().Deployments(namespace.Name).List(context.Background(), metav1.ListOptions{})
+	list, err := exutil.ListWithRetry(f.ClientSet.AppsV1().Deployments(namespace.Name).List)
 	Expect(err).NotTo(HaveOccurred())
 
 	return list.Items
 }
 
 func getNamespaceStatefulSets(f *e2e.Framework, namespace corev1.Namespace) []appsv1.StatefulSet {
-	list, err := f.ClientSet.AppsV1().StatefulSets(namespace.Name).List(context.Background(), metav1.ListOptions{})
+	list, err := exutil.ListWithRetry(f.ClientSet.AppsV1().StatefulSets(namespace.Name).List)
 	Expect(err).NotTo(HaveOccurred())
 
 	return list.Items
 }
 
+func getNamespaceDaemonSets(f *e2e.Framework, namespace corev1.Namespace) []appsv1.DaemonSet {
+	list, err := exutil.ListWithRetry(f.ClientSet.AppsV1().DaemonSets(namespace.Name).List)
+	Expect(err).NotTo(HaveOccurred())
+
+	return list.Items
+}
+
+func getNamespaceCronJobs(f *e2e.Framework, namespace corev1.Namespace) []batchv1.CronJob {
+	list, err := exutil.ListWithRetry(f.ClientSet.BatchV1().CronJobs(namespace.Name).List)
+	Expect(err).NotTo(HaveOccurred())
+
+	return list.Items
+}
+
+func getNamespaceJobs(f *e2e.Framework, namespace corev1.Namespace) []batchv1.Job {
+	list, err := exutil.ListWithRetry(f.ClientSet.BatchV1().Jobs(namespace.Name).List)
+	Expect(err).NotTo(HaveOccurred())
+
+	return list.Items
+}
+
+func getNamespaceHorizontalPodAutoscalers(f *e2e.Framework, namespace corev1.Namespace) []autoscalingv2.HorizontalPodAutoscaler {
+	list, err := exutil.ListWithRetry(f.ClientSet.AutoscalingV2().HorizontalPodAutoscalers(namespace.Name).List)
+	Expect(err).NotTo(HaveOccurred())
+
+	return list.Items
+}
+
+// getNamespaceWorkloads gathers every workload kind this test understands how to validate,
+// in the given namespace.
+func getNamespaceWorkloads(f *e2e.Framework, namespace corev1.Namespace) []WorkloadValidator {
+	var workloads []WorkloadValidator
+
+	for _, deployment := range getNamespaceDeployments(f, namespace) {
+		workloads = append(workloads, deploymentValidator{deployment: deployment})
+	}
+
+	for _, statefulSet := range getNamespaceStatefulSets(f, namespace) {
+		workloads = append(workloads, statefulSetValidator{statefulSet: statefulSet})
+	}
+
+	for _, daemonSet := range getNamespaceDaemonSets(f, namespace) {
+		workloads = append(workloads, daemonSetValidator{daemonSet: daemonSet})
+	}
+
+	for _, cronJob := range getNamespaceCronJobs(f, namespace) {
+		workloads = append(workloads, cronJobValidator{cronJob: cronJob})
+	}
+
+	for _, job := range getNamespaceJobs(f, namespace) {
+		workloads = append(workloads, jobValidator{job: job})
+	}
+
+	for _, hpa := range getNamespaceHorizontalPodAutoscalers(f, namespace) {
+		workloads = append(workloads, horizontalPodAutoscalerValidator{horizontalPodAutoscaler: hpa})
+	}
+
+	return workloads
+}
+
 func getTopologies(f *e2e.Framework) (controlPlaneTopology, infraTopology v1.TopologyMode) {
 	oc := exutil.NewCLIWithFramework(f)
 	infra, err := oc.AdminConfigClient().ConfigV1().Infrastructures().Get(context.Background(),
@@ -51,170 +132,200 @@ func getTopologies(f *e2e.Framework) (controlPlaneTopology, infraTopology v1.Top
 	return infra.Status.ControlPlaneTopology, infra.Status.InfrastructureTopology
 }
 
-// isInfrastructureStatefulSet decides if a StatefulSet is considered "infrastructure" or
-// "control plane" by comparing it against a known list
-func isInfrastructureStatefulSet(statefulSet appsv1.StatefulSet) bool {
-	infrastructureNamespaces := map[string][]string{
-		// No known OpenShift StatefulSets are considered "infrastructure" for now
-	}
+// getControlPlaneNodeCount returns the number of control plane (master) nodes in the cluster,
+// used to compute the expected replica count for workloads running under HighlyAvailable
+// topology. It deliberately excludes worker nodes, which outnumber masters on a standard
+// HighlyAvailable cluster and would otherwise inflate the expected count.
+func getControlPlaneNodeCount(f *e2e.Framework) int {
+	list, err := f.ClientSet.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{
+		LabelSelector: topology.MasterNodeRoleLabel,
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	return len(list.Items)
+}
 
-	namespaceInfraStatefulSets, ok := infrastructureNamespaces[statefulSet.Namespace]
+func validateReplicas(name, namespace string, replicas int, failureAllowed bool) {
+	validateReplicaExpectation(name, namespace, replicas == 1, failureAllowed)
+}
 
-	if !ok {
-		return false
+// validateReplicaExpectation reports a workload whose replica/scheduling/parallelism count
+// doesn't match what's expected for the cluster's topology, unless it's still on the allow-list.
+func validateReplicaExpectation(name, namespace string, asExpected, failureAllowed bool) {
+	if !failureAllowed {
+		Expect(asExpected).To(BeTrue(),
+			"%s in %s namespace has wrong number of replicas", name, namespace)
+	} else if asExpected {
+		t := GinkgoT()
+		t.Logf("%s in namespace %s has the expected number of replicas, consider taking it off the topology allow-list",
+			name, namespace)
 	}
+}
 
-	for _, infraStatefulSetName := range namespaceInfraStatefulSets {
-		if statefulSet.Name == infraStatefulSetName {
-			return true
-		}
+func validateStatefulSetReplicas(statefulSet appsv1.StatefulSet, ctx topologyContext) {
+	Expect(statefulSet.Spec.Replicas).ToNot(BeNil())
+
+	isInfra := ctx.expectations.IsInfrastructure(KindStatefulSet, statefulSet.Name, statefulSet.Namespace)
+	if !topology.Applicable(isInfra, ctx.controlPlaneTopology, ctx.infraTopology, ctx.nodeCount) {
+		return
 	}
 
-	return false
+	statefulSet = waitForStatefulSetReady(ctx.framework, statefulSet)
+	_, ok := topology.IsReplicaCountExpected(isInfra, ctx.controlPlaneTopology, ctx.infraTopology, ctx.nodeCount, int(*statefulSet.Spec.Replicas))
+
+	failureAllowed := ctx.expectations.IsAllowedToFail(KindStatefulSet, statefulSet.Name, statefulSet.Namespace)
+	validateReplicaExpectation(statefulSet.Name, statefulSet.Namespace, ok, failureAllowed)
 }
 
-// isInfrastructureDeployment decides if a deployment is considered "infrastructure" or
-// "control plane" by comparing it against a known list
-func isInfrastructureDeployment(deployment appsv1.Deployment) bool {
-	infrastructureNamespaces := map[string][]string{
-		"openshift-ingress": {
-			"router-default",
-		},
+func validateDeploymentReplicas(deployment appsv1.Deployment, ctx topologyContext) {
+	Expect(deployment.Spec.Replicas).ToNot(BeNil())
+
+	isInfra := ctx.expectations.IsInfrastructure(KindDeployment, deployment.Name, deployment.Namespace)
+	if !topology.Applicable(isInfra, ctx.controlPlaneTopology, ctx.infraTopology, ctx.nodeCount) {
+		return
 	}
 
-	namespaceInfraDeployments, ok := infrastructureNamespaces[deployment.Namespace]
+	deployment = waitForDeploymentReady(ctx.framework, deployment)
+	_, ok := topology.IsReplicaCountExpected(isInfra, ctx.controlPlaneTopology, ctx.infraTopology, ctx.nodeCount, int(*deployment.Spec.Replicas))
 
-	if !ok {
-		return false
-	}
+	failureAllowed := ctx.expectations.IsAllowedToFail(KindDeployment, deployment.Name, deployment.Namespace)
+	validateReplicaExpectation(deployment.Name, deployment.Namespace, ok, failureAllowed)
+}
 
-	for _, infraDeploymentName := range namespaceInfraDeployments {
-		if deployment.Name == infraDeploymentName {
-			return true
-		}
+// validateDaemonSetScheduling checks that a DaemonSet wants exactly one pod scheduled on a
+// single node cluster (Status.DesiredNumberScheduled == 1). A DaemonSet whose node selector and
+// tolerations match no node, and so schedules no pod, is reported as well.
+func validateDaemonSetScheduling(daemonSet appsv1.DaemonSet, ctx topologyContext) {
+	if ctx.controlPlaneTopology != v1.SingleReplicaTopologyMode {
+		return
 	}
 
-	return false
+	failureAllowed := ctx.expectations.IsAllowedToFail(KindDaemonSet, daemonSet.Name, daemonSet.Namespace)
+	validateReplicas(daemonSet.Name, daemonSet.Namespace, int(daemonSet.Status.DesiredNumberScheduled), failureAllowed)
 }
 
-func validateReplicas(name, namespace string, replicas int, failureAllowed bool) {
+// validateCronJobConcurrency checks that a CronJob does not allow overlapping runs, which on a
+// single node cluster can blow through the resource budget that was sized for one pod at a time.
+func validateCronJobConcurrency(cronJob batchv1.CronJob, ctx topologyContext) {
+	if ctx.controlPlaneTopology != v1.SingleReplicaTopologyMode {
+		return
+	}
+
+	isAllowConcurrent := cronJob.Spec.ConcurrencyPolicy == batchv1.AllowConcurrent
+	failureAllowed := ctx.expectations.IsAllowedToFail(KindCronJob, cronJob.Name, cronJob.Namespace)
+
 	if !failureAllowed {
-		Expect(replicas).To(Equal(1),
-			"%s in %s namespace has wrong number of replicas", name, namespace)
-	} else {
-		if replicas == 1 {
-			t := GinkgoT()
-			t.Logf("%s in namespace %s has one replica, consider taking it off the topology allow-list",
-				name, namespace)
-		}
+		Expect(isAllowConcurrent).To(BeFalse(),
+			"%s in %s namespace allows concurrent execution, which can exceed resource budgets on a single node",
+			cronJob.Name, cronJob.Namespace)
+	} else if !isAllowConcurrent {
+		t := GinkgoT()
+		t.Logf("%s in namespace %s no longer allows concurrent execution, consider taking it off the topology allow-list",
+			cronJob.Name, cronJob.Namespace)
 	}
 }
 
-func validateStatefulSetReplicas(statefulSet appsv1.StatefulSet, controlPlaneTopology,
-	infraTopology v1.TopologyMode, failureAllowed bool) {
-	if isInfrastructureStatefulSet(statefulSet) {
-		if infraTopology != v1.SingleReplicaTopologyMode {
-			return
-		}
-	} else if controlPlaneTopology != v1.SingleReplicaTopologyMode {
+// validateJobParallelism checks that a Job is configured to run exactly one pod at a time on a
+// single node cluster. An unset parallelism counts as one; a parallelism of 0 is reported.
+func validateJobParallelism(job batchv1.Job, ctx topologyContext) {
+	if ctx.controlPlaneTopology != v1.SingleReplicaTopologyMode {
 		return
 	}
 
-	Expect(statefulSet.Spec.Replicas).ToNot(BeNil())
+	parallelism := int32(1)
+	if job.Spec.Parallelism != nil {
+		parallelism = *job.Spec.Parallelism
+	}
 
-	validateReplicas(statefulSet.Name, statefulSet.Namespace, int(*statefulSet.Spec.Replicas), failureAllowed)
+	failureAllowed := ctx.expectations.IsAllowedToFail(KindJob, job.Name, job.Namespace)
+	validateReplicas(job.Name, job.Namespace, int(parallelism), failureAllowed)
 }
 
-func validateDeploymentReplicas(deployment appsv1.Deployment,
-	controlPlaneTopology, infraTopology v1.TopologyMode, failureAllowed bool) {
-	if isInfrastructureDeployment(deployment) {
-		if infraTopology != v1.SingleReplicaTopologyMode {
-			return
-		}
-	} else if controlPlaneTopology != v1.SingleReplicaTopologyMode {
+// validateHorizontalPodAutoscalerBounds checks that an HPA cannot scale its target past a single
+// replica on a single node cluster, e.g. an HPA left pointed at a control plane Deployment that
+// would otherwise be pinned to one replica.
+func validateHorizontalPodAutoscalerBounds(hpa autoscalingv2.HorizontalPodAutoscaler, ctx topologyContext) {
+	if ctx.controlPlaneTopology != v1.SingleReplicaTopologyMode {
 		return
 	}
 
-	Expect(deployment.Spec.Replicas).ToNot(BeNil())
+	failureAllowed := ctx.expectations.IsAllowedToFail(KindHorizontalPodAutoscaler, hpa.Name, hpa.Namespace)
+	validateReplicas(hpa.Name, hpa.Namespace, int(hpa.Spec.MaxReplicas), failureAllowed)
+}
 
-	validateReplicas(deployment.Name, deployment.Namespace, int(*deployment.Spec.Replicas), failureAllowed)
-}
-
-func isAllowedToFail(name, namespace string) bool {
-	// allowedToFail is a list of deployments and statefulsets that currently have 2 replicas
-	// even in single-replica topology deployments, because their operator has yet to be made
-	// aware of the new API. We will slowly remove deployments from this list once their operators
-	// have been made aware, until this list is empty and this function will be removed.
-	allowedToFail := map[string][]string{
-		"openshift-authentication": {
-			// Deployments
-			"oauth-openshift",
-		},
-		"openshift-console": {
-			// Deployments
-			"console",
-			"downloads",
-		},
-		"openshift-image-registry": {
-			"image-registry",
-		},
-		"openshift-monitoring": {
-			// Deployments
-			"prometheus-adapter",
-			"thanos-querier",
-
-			// StatefulSets
-			"alertmanager-main",
-			"prometheus-k8s",
-		},
-		"openshift-operator-lifecycle-manager": {
-			// Deployments
-			"packageserver",
-		},
-	}
-
-	namespaceAllowedToFailDeployments, ok := allowedToFail[namespace]
-
-	if !ok {
-		return false
-	}
-
-	for _, allowedToFailDeploymentName := range namespaceAllowedToFailDeployments {
-		if name == allowedToFailDeploymentName {
-			return true
-		}
-	}
+type deploymentValidator struct {
+	deployment appsv1.Deployment
+}
+
+func (v deploymentValidator) Validate(ctx topologyContext) {
+	validateDeploymentReplicas(v.deployment, ctx)
+}
+
+type statefulSetValidator struct {
+	statefulSet appsv1.StatefulSet
+}
+
+func (v statefulSetValidator) Validate(ctx topologyContext) {
+	validateStatefulSetReplicas(v.statefulSet, ctx)
+}
+
+type daemonSetValidator struct {
+	daemonSet appsv1.DaemonSet
+}
 
-	return false
+func (v daemonSetValidator) Validate(ctx topologyContext) {
+	validateDaemonSetScheduling(v.daemonSet, ctx)
 }
 
-func isDeploymentAllowedToFail(deployment appsv1.Deployment) bool {
-	return isAllowedToFail(deployment.Name, deployment.Namespace)
+type cronJobValidator struct {
+	cronJob batchv1.CronJob
 }
 
-func isStatefulSetAllowedToFail(statefulSet appsv1.StatefulSet) bool {
-	return isAllowedToFail(statefulSet.Name, statefulSet.Namespace)
+func (v cronJobValidator) Validate(ctx topologyContext) {
+	validateCronJobConcurrency(v.cronJob, ctx)
+}
+
+type jobValidator struct {
+	job batchv1.Job
+}
+
+func (v jobValidator) Validate(ctx topologyContext) {
+	validateJobParallelism(v.job, ctx)
+}
+
+type horizontalPodAutoscalerValidator struct {
+	horizontalPodAutoscaler autoscalingv2.HorizontalPodAutoscaler
+}
+
+func (v horizontalPodAutoscalerValidator) Validate(ctx topologyContext) {
+	validateHorizontalPodAutoscalerBounds(v.horizontalPodAutoscaler, ctx)
 }
 
 var _ = Describe("[sig-arch] Cluster topology single node tests", func() {
 	f := e2e.NewDefaultFramework("single-node")
 
-	It("Verify that OpenShift components deploy one replica in SingleReplica topology mode", func() {
+	It("Verify that OpenShift components deploy the expected number of replicas for the cluster's topology", func() {
 		controlPlaneTopology, infraTopology := getTopologies(f)
+		nodeCount := getControlPlaneNodeCount(f)
 
-		if controlPlaneTopology != v1.SingleReplicaTopologyMode && infraTopology != v1.SingleReplicaTopologyMode {
-			e2eskipper.Skipf("Test is only relevant for single replica topologies")
+		if !topology.Applicable(false, controlPlaneTopology, infraTopology, nodeCount) &&
+			!topology.Applicable(true, controlPlaneTopology, infraTopology, nodeCount) {
+			e2eskipper.Skipf("Test has no replica expectations for topology (control plane=%s, infra=%s)",
+				controlPlaneTopology, infraTopology)
 		}
 
-		for _, namespace := range getOpenshiftNamespaces(f) {
-			for _, deployment := range getNamespaceDeployments(f, namespace) {
-				validateDeploymentReplicas(deployment,
-					controlPlaneTopology, infraTopology, isDeploymentAllowedToFail(deployment))
-			}
+		ctx := topologyContext{
+			framework:            f,
+			controlPlaneTopology: controlPlaneTopology,
+			infraTopology:        infraTopology,
+			nodeCount:            nodeCount,
+			expectations:         loadTopologyExpectations(f),
+		}
+		logAllowList(ctx.expectations)
 
-			for _, statefulSet := range getNamespaceStatefulSets(f, namespace) {
-				validateStatefulSetReplicas(statefulSet,
-					controlPlaneTopology, infraTopology, isStatefulSetAllowedToFail(statefulSet))
+		for _, namespace := range getOpenshiftNamespaces(f) {
+			for _, workload := range getNamespaceWorkloads(f, namespace) {
+				workload.Validate(ctx)
 			}
 		}
 	})
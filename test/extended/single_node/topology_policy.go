@@ -0,0 +1,75 @@
+package single_node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/origin/pkg/topology"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	topologyExpectationsNamespace     = "openshift-config"
+	topologyExpectationsConfigMapName = "topology-expectations"
+	topologyExpectationsConfigMapKey  = "expectations.yaml"
+)
+
+// WorkloadKind and the per-kind constants, along with TopologyExpectation(s), live in
+// pkg/topology so an in-cluster controller that imports that package can evaluate workloads the
+// exact same way this e2e does.
+type (
+	WorkloadKind         = topology.WorkloadKind
+	TopologyExpectation  = topology.Expectation
+	TopologyExpectations = topology.Expectations
+)
+
+const (
+	KindDeployment              = topology.KindDeployment
+	KindStatefulSet             = topology.KindStatefulSet
+	KindDaemonSet               = topology.KindDaemonSet
+	KindCronJob                 = topology.KindCronJob
+	KindJob                     = topology.KindJob
+	KindHorizontalPodAutoscaler = topology.KindHorizontalPodAutoscaler
+)
+
+// loadTopologyExpectations reads the TopologyExpectations ConfigMap from openshift-config, if
+// present, and otherwise falls back to the expectations compiled into this test.
+func loadTopologyExpectations(f *e2e.Framework) TopologyExpectations {
+	configMap, err := f.ClientSet.CoreV1().ConfigMaps(topologyExpectationsNamespace).Get(context.Background(),
+		topologyExpectationsConfigMapName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return topology.Embedded()
+	}
+	Expect(err).NotTo(HaveOccurred())
+
+	var entries []TopologyExpectation
+	Expect(yaml.Unmarshal([]byte(configMap.Data[topologyExpectationsConfigMapKey]), &entries)).To(Succeed(),
+		"failed to parse %s/%s configmap key %q", topologyExpectationsNamespace, topologyExpectationsConfigMapName,
+		topologyExpectationsConfigMapKey)
+
+	return TopologyExpectations{Entries: entries}
+}
+
+// logAllowList prints every TopologyExpectations entry still marked allowedToFail as a single,
+// grep-friendly log line in this test's output, so release-gating dashboards that scrape CI logs
+// can track allow-list burn-down over time. This repo's Ginkgo version has no structured
+// per-spec JUnit property API to attach this to instead, so a log line is the most this can do
+// today.
+func logAllowList(expectations TopologyExpectations) {
+	var stillAllowed []string
+	for _, entry := range expectations.Entries {
+		if entry.AllowedToFail {
+			stillAllowed = append(stillAllowed, fmt.Sprintf("%s/%s/%s(%s)", entry.Kind, entry.Namespace, entry.Name, entry.BugID))
+		}
+	}
+	sort.Strings(stillAllowed)
+
+	e2e.Logf("topology-allow-list=%s", strings.Join(stillAllowed, ","))
+}
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	retryInterval = 2 * time.Second
+	retryTimeout  = 30 * time.Second
+)
+
+// ListWithRetry retries a List call for up to 30s, to ride out the transient apiserver errors
+// (e.g. 503s during etcd compaction or a control-plane pod restart) that are common on
+// resource-constrained clusters and would otherwise fail a test on a single blip.
+func ListWithRetry[T any](list func(context.Context, metav1.ListOptions) (T, error)) (T, error) {
+	var result T
+	var lastErr error
+
+	if err := wait.PollImmediate(retryInterval, retryTimeout, func() (bool, error) {
+		var err error
+		result, err = list(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+
+		return true, nil
+	}); err != nil {
+		return result, lastErr
+	}
+
+	return result, nil
+}
+
+// GetWithRetry retries a Get call for up to 30s, for the same reason as ListWithRetry.
+func GetWithRetry[T any](get func(context.Context, string, metav1.GetOptions) (T, error), name string) (T, error) {
+	var result T
+	var lastErr error
+
+	if err := wait.PollImmediate(retryInterval, retryTimeout, func() (bool, error) {
+		var err error
+		result, err = get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+
+		return true, nil
+	}); err != nil {
+		return result, lastErr
+	}
+
+	return result, nil
+}